@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// parseConsulSource splits a "consul://host:port/kv/path/to/identities"
+// source into the Consul HTTP address and the KV key to read.
+func parseConsulSource(source string) (address, kvPath string, err error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "consul" {
+		return "", "", fmt.Errorf("not a consul:// source: %s", source)
+	}
+
+	kvPath = strings.TrimPrefix(u.Path, "/kv/")
+	kvPath = strings.TrimPrefix(kvPath, "/")
+	if kvPath == "" {
+		return "", "", fmt.Errorf("consul source must include a /kv/<path>: %s", source)
+	}
+
+	return u.Host, kvPath, nil
+}
+
+// newConsulClient builds a client honoring CONSUL_HTTP_ADDR and
+// CONSUL_HTTP_TOKEN, falling back to the address embedded in the source URL
+// when the host:port is given explicitly.
+func newConsulClient(address string) (*api.Client, error) {
+	config := api.DefaultConfig()
+	if address != "" {
+		config.Address = address
+	}
+	return api.NewClient(config)
+}
+
+// fetchYAMLFromConsul reads the identities YAML from a Consul KV entry for a
+// one-shot `filter` invocation.
+func fetchYAMLFromConsul(source string) ([]byte, error) {
+	address, kvPath, err := parseConsulSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newConsulClient(address)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, _, err := client.KV().Get(kvPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("no value found at consul path %q", kvPath)
+	}
+
+	return pair.Value, nil
+}
+
+// consulBlockingWait caps how long a single blocking query may hang before
+// it's retried, so the watch loop can't wedge forever on a partitioned agent.
+const consulBlockingWait = 5 * time.Minute
+
+// watchConsul loads the identities once, then keeps the store in sync with
+// Consul KV changes using blocking queries, so `serve` mode reacts to
+// updates without polling on a fixed interval.
+func (s *IdentityStore) watchConsul(source string) error {
+	address, kvPath, err := parseConsulSource(source)
+	if err != nil {
+		return err
+	}
+
+	client, err := newConsulClient(address)
+	if err != nil {
+		return err
+	}
+
+	pair, meta, err := client.KV().Get(kvPath, nil)
+	if err != nil {
+		return err
+	}
+	if pair == nil {
+		return fmt.Errorf("no value found at consul path %q", kvPath)
+	}
+	if err := s.load(pair.Value); err != nil {
+		return err
+	}
+
+	go func() {
+		waitIndex := meta.LastIndex
+		for {
+			pair, meta, err := client.KV().Get(kvPath, &api.QueryOptions{
+				WaitIndex: waitIndex,
+				WaitTime:  consulBlockingWait,
+			})
+			if err != nil {
+				log.Printf("Warning: consul blocking query for %s failed: %v", kvPath, err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+
+			waitIndex = meta.LastIndex
+			if pair == nil {
+				continue
+			}
+
+			if err := s.load(pair.Value); err != nil {
+				log.Printf("Warning: failed to parse identities from consul path %s: %v", kvPath, err)
+			}
+		}
+	}()
+
+	return nil
+}
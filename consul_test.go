@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestParseConsulSource(t *testing.T) {
+	address, kvPath, err := parseConsulSource("consul://consul.internal:8500/kv/path/to/identities")
+	if err != nil {
+		t.Fatalf("Failed to parse consul source: %v", err)
+	}
+	if address != "consul.internal:8500" {
+		t.Errorf("Expected address consul.internal:8500, got %q", address)
+	}
+	if kvPath != "path/to/identities" {
+		t.Errorf("Expected kvPath path/to/identities, got %q", kvPath)
+	}
+}
+
+func TestParseConsulSourceMissingKVPath(t *testing.T) {
+	if _, _, err := parseConsulSource("consul://consul.internal:8500"); err == nil {
+		t.Error("Expected an error for a consul source with no /kv/<path>")
+	}
+}
+
+func TestParseConsulSourceWrongScheme(t *testing.T) {
+	if _, _, err := parseConsulSource("https://example.com/kv/path"); err == nil {
+		t.Error("Expected an error for a non-consul:// source")
+	}
+}
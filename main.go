@@ -8,6 +8,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,15 +19,62 @@ import (
 
 // Config struct to hold a map of identities
 type Config struct {
+	Defaults   Defaults            `yaml:"defaults"`
 	Identities map[string]Identity `yaml:"identities"`
 }
 
-type Identity struct {
-	Enabled   bool   `yaml:"enabled"`
+// Defaults holds fallback values inherited by any identity entry that
+// doesn't set its own reason or expiry, so policies don't have to repeat
+// the same boilerplate on every entry.
+type Defaults struct {
 	Reason    string `yaml:"reason,omitempty"`
 	ExpiresOn string `yaml:"expires-on,omitempty"`
 }
 
+// applyDefaults fills in any entry's missing reason/expiry from the
+// top-level defaults block, so policies don't repeat the same values on
+// every entry.
+func (c *Config) applyDefaults() {
+	for name, identity := range c.Identities {
+		if identity.Reason == "" {
+			identity.Reason = c.Defaults.Reason
+		}
+		if identity.ExpiresOn == "" {
+			identity.ExpiresOn = c.Defaults.ExpiresOn
+		}
+		c.Identities[name] = identity
+	}
+}
+
+// Identity describes a suppression policy. A fingerprint-keyed entry with no
+// other predicates matches a result by exact `fingerprints.identity` equality,
+// as before. An entry may instead (or additionally) declare RuleID, URIGlob,
+// Level, and/or a priority-score range; when any of those are set, the entry
+// matches results by those predicates instead of requiring a known
+// fingerprint, so teams can write policies without enumerating UUIDs.
+//
+// ExpiresOn means opposite things depending on which style an entry uses, a
+// quirk worth knowing before mixing the two in one YAML:
+//   - Fingerprint-only entries (no RuleID/URIGlob/Level/priority-score) only
+//     start suppressing their result once ExpiresOn has passed. Before that
+//     date the result is kept/visible; this preserves this tool's original,
+//     long-standing behavior.
+//   - Predicate-based entries (RuleID/URIGlob/Level/priority-score) suppress
+//     up until ExpiresOn and then stop, which is the intuitive "this
+//     suppression lapses on this date" reading.
+//
+// See matchEntry for where this split is implemented.
+type Identity struct {
+	Enabled          bool   `yaml:"enabled"`
+	Reason           string `yaml:"reason,omitempty"`
+	ExpiresOn        string `yaml:"expires-on,omitempty"`
+	RuleID           string `yaml:"rule-id,omitempty"`
+	URIGlob          string `yaml:"uri-glob,omitempty"`
+	Level            string `yaml:"level,omitempty"`
+	MinPriorityScore *int   `yaml:"min-priority-score,omitempty"`
+	MaxPriorityScore *int   `yaml:"max-priority-score,omitempty"`
+}
+
 type Sarif struct {
 	Schema  string `json:"$schema"`
 	Version string `json:"version"`
@@ -66,6 +115,16 @@ type Result struct {
 	Fingerprints map[string]string `json:"fingerprints"`
 	CodeFlows    []CodeFlow        `json:"codeFlows"`
 	Properties   Properties        `json:"properties"`
+	Suppressions []Suppression     `json:"suppressions,omitempty"`
+}
+
+// Suppression records why a result was exempted from a scan, per the SARIF
+// 2.1.0 suppressions object, so tools like GitHub code scanning can keep
+// showing the finding alongside its justification instead of losing it.
+type Suppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
 }
 
 type Message struct {
@@ -120,6 +179,12 @@ type PriorityFactor struct {
 
 // ... (Rest of the SARIF data structures remain the same) ...
 
+// Filter modes for the "filter" command's --mode flag.
+const (
+	ModeDrop     = "drop"
+	ModeSuppress = "suppress"
+)
+
 func main() {
 	var cmdFilter = &cobra.Command{
 		Use:   "filter",
@@ -127,20 +192,35 @@ func main() {
 		Run: func(cmd *cobra.Command, args []string) {
 			sarifFile, _ := cmd.Flags().GetString("sarif")
 			identitiesSource, _ := cmd.Flags().GetString("identities-file")
+			mode, _ := cmd.Flags().GetString("mode")
+			reportPath, _ := cmd.Flags().GetString("report")
+			outputFormat, _ := cmd.Flags().GetString("output-format")
+			reportIncludeExpired, _ := cmd.Flags().GetBool("report-include-expired")
 
 			if sarifFile == "" || identitiesSource == "" {
 				log.Fatal("Please provide both --sarif and --identities-file flags")
 			}
 
+			if mode != ModeDrop && mode != ModeSuppress {
+				log.Fatalf("Invalid --mode %q, must be %q or %q", mode, ModeDrop, ModeSuppress)
+			}
+
+			if outputFormat != OutputFormatSarif && outputFormat != OutputFormatNone {
+				log.Fatalf("Invalid --output-format %q, must be %q or %q", outputFormat, OutputFormatSarif, OutputFormatNone)
+			}
+
 			sarifData, err := ioutil.ReadFile(sarifFile)
 			if err != nil {
 				log.Fatal(err)
 			}
 
 			var yamlData []byte
-			if strings.HasPrefix(identitiesSource, "http://") || strings.HasPrefix(identitiesSource, "https://") {
+			switch {
+			case strings.HasPrefix(identitiesSource, "http://") || strings.HasPrefix(identitiesSource, "https://"):
 				yamlData, err = fetchYAMLFromURL(identitiesSource)
-			} else {
+			case strings.HasPrefix(identitiesSource, "consul://"):
+				yamlData, err = fetchYAMLFromConsul(identitiesSource)
+			default:
 				yamlData, err = ioutil.ReadFile(identitiesSource)
 			}
 			if err != nil {
@@ -152,6 +232,7 @@ func main() {
 			if err != nil {
 				log.Fatal(err)
 			}
+			config.applyDefaults()
 
 			var sarif Sarif
 			err = json.Unmarshal(sarifData, &sarif)
@@ -161,17 +242,29 @@ func main() {
 
 			currentTime := time.Now() // Get current time
 
-			filteredSarif := filterIdentities(&sarif, config.Identities, currentTime)
-			filteredSarifJSON, err := json.MarshalIndent(filteredSarif, "", "  ")
-			if err != nil {
+			filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, mode)
+
+			var report *Report
+			if reportPath != "" {
+				report = buildReport(&sarif, config.Identities, currentTime, mode)
+			}
+
+			writer := NewResultsWriter().
+				SetReportPath(reportPath).
+				SetOutputFormat(outputFormat).
+				SetIncludeExpired(reportIncludeExpired)
+			if err := writer.Write(filteredSarif, report); err != nil {
 				log.Fatal(err)
 			}
-			fmt.Println(string(filteredSarifJSON))
 		},
 	}
 
 	cmdFilter.Flags().StringP("sarif", "s", "", "Path to the SARIF file")
-	cmdFilter.Flags().StringP("identities-file", "i", "", "Path or URL to the YAML file with identities. Format: identities: {fingerprint: bool, ...}")
+	cmdFilter.Flags().StringP("identities-file", "i", "", "Path, URL, or consul://host:port/kv/path to the YAML file with identities (note: expires-on suppresses after the date for plain fingerprint entries, but until the date for rule-id/uri-glob/level/priority-score entries; see Identity)")
+	cmdFilter.Flags().String("mode", ModeDrop, "How to handle filtered results: \"drop\" removes them, \"suppress\" keeps them and annotates a SARIF suppressions entry")
+	cmdFilter.Flags().String("report", "", "Path to write a JSON audit report summarizing what was filtered, dead policies, and per-rule counts")
+	cmdFilter.Flags().String("output-format", OutputFormatSarif, "How to present the filtered SARIF: \"sarif\" prints it to stdout, \"none\" suppresses it (useful with --report)")
+	cmdFilter.Flags().Bool("report-include-expired", true, "Include expired identity entries in the --report output")
 
 	var rootCmd = &cobra.Command{
 		Use:   "sarif-filter",
@@ -179,6 +272,7 @@ func main() {
 	}
 
 	rootCmd.AddCommand(cmdFilter)
+	rootCmd.AddCommand(newServeCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -200,7 +294,7 @@ func fetchYAMLFromURL(url string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-func filterIdentities(sarif *Sarif, identities map[string]Identity, currentTime time.Time) *Sarif {
+func filterIdentities(sarif *Sarif, identities map[string]Identity, currentTime time.Time, mode string) *Sarif {
 	filteredSarif := &Sarif{
 		Schema:  sarif.Schema,
 		Version: sarif.Version,
@@ -210,7 +304,7 @@ func filterIdentities(sarif *Sarif, identities map[string]Identity, currentTime
 	for i, run := range sarif.Runs {
 		filteredRun := Run{
 			Tool:    run.Tool,
-			Results: filterResults(run.Results, identities, currentTime),
+			Results: filterResults(run.Results, identities, currentTime, mode),
 		}
 		filteredSarif.Runs[i] = filteredRun
 	}
@@ -218,12 +312,18 @@ func filterIdentities(sarif *Sarif, identities map[string]Identity, currentTime
 	return filteredSarif
 }
 
-func filterResults(results []Result, identities map[string]Identity, currentTime time.Time) []Result {
+func filterResults(results []Result, identities map[string]Identity, currentTime time.Time, mode string) []Result {
 	var filteredResults []Result
 
 	for _, result := range results {
-		identity := result.Fingerprints["identity"]
-		if shouldKeepResult(identity, identities, currentTime) {
+		_, matched, ok := findMatch(result, identities, currentTime)
+		if !ok {
+			filteredResults = append(filteredResults, result)
+			continue
+		}
+
+		if mode == ModeSuppress {
+			result.Suppressions = append(result.Suppressions, buildSuppression(matched))
 			filteredResults = append(filteredResults, result)
 		}
 	}
@@ -231,17 +331,154 @@ func filterResults(results []Result, identities map[string]Identity, currentTime
 	return filteredResults
 }
 
-func shouldKeepResult(identity string, identities map[string]Identity, currentTime time.Time) bool {
-	identityConfig, exists := identities[identity]
-	if !exists {
-		return true // Keep the result if the identity is not in the config
+// buildSuppression turns a matched identity entry into a SARIF suppression
+// object so the result stays visible with its exemption annotated rather
+// than disappearing from the output.
+func buildSuppression(identity Identity) Suppression {
+	justification := identity.Reason
+	if identity.ExpiresOn != "" {
+		if justification != "" {
+			justification += " "
+		}
+		justification += fmt.Sprintf("(expires %s)", identity.ExpiresOn)
+	}
+
+	return Suppression{
+		Kind:          "external",
+		Status:        "accepted",
+		Justification: justification,
+	}
+}
+
+// findMatch returns the name and entry of the enabled, non-expired identity
+// entry whose predicates match result. An entry with no rule-id/uri-glob/
+// level/priority-score predicates falls back to the original
+// exact-fingerprint match against its map key.
+//
+// Now that chunk0-3 allows a fingerprint entry and a rule-id/uri-glob entry
+// to target the same result, more than one entry can match at once. Ties are
+// broken by sorting candidate names so the choice — and the justification
+// text written into suppress-mode output — is stable across runs of the
+// same input, rather than depending on Go's randomized map iteration order.
+func findMatch(result Result, identities map[string]Identity, currentTime time.Time) (string, Identity, bool) {
+	var candidates []string
+	for name, identity := range identities {
+		if matchEntry(name, identity, result, currentTime) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", Identity{}, false
 	}
 
-	if !identityConfig.Enabled {
-		return true // Keep the result if the identity is not enabled
+	sort.Strings(candidates)
+	name := candidates[0]
+	return name, identities[name], true
+}
+
+// matchEntry implements the ExpiresOn asymmetry documented on Identity: the
+// legacy fingerprint-only path suppresses after expiry, the predicate-based
+// path suppresses until expiry.
+func matchEntry(name string, identity Identity, result Result, currentTime time.Time) bool {
+	if !identity.Enabled {
+		return false
+	}
+
+	if !identity.hasPredicates() {
+		// Legacy exact-fingerprint matching: preserved exactly as it always
+		// behaved, where an identity only suppresses its result once it has
+		// passed expires-on rather than before.
+		return identityTargetsResult(name, identity, result) && isExpired(identity, currentTime)
+	}
+
+	if isExpired(identity, currentTime) {
+		return false
+	}
+
+	return identityTargetsResult(name, identity, result)
+}
+
+// identityTargetsResult reports whether identity's predicates describe
+// result, ignoring enabled/expiry state entirely. matchEntry layers the
+// legacy path's inverted expiry gate on top of this; callers that just want
+// to know whether an entry's target ever appears in the input — such as
+// dead-policy detection in the audit report — should call this directly
+// instead of matchEntry, which answers a different question (is this entry
+// actively suppressing right now).
+func identityTargetsResult(name string, identity Identity, result Result) bool {
+	if !identity.hasPredicates() {
+		return name == result.Fingerprints["identity"]
+	}
+
+	if identity.RuleID != "" && identity.RuleID != result.RuleID {
+		return false
+	}
+	if identity.Level != "" && identity.Level != result.Level {
+		return false
+	}
+	if identity.MinPriorityScore != nil && result.Properties.PriorityScore < *identity.MinPriorityScore {
+		return false
+	}
+	if identity.MaxPriorityScore != nil && result.Properties.PriorityScore > *identity.MaxPriorityScore {
+		return false
+	}
+	if identity.URIGlob != "" && !matchesURIGlob(identity.URIGlob, result) {
+		return false
+	}
+
+	return true
+}
+
+// hasPredicates reports whether identity declares any of the richer
+// matching predicates, as opposed to relying solely on its map key matching
+// a result's fingerprint.
+func (identity Identity) hasPredicates() bool {
+	return identity.RuleID != "" || identity.URIGlob != "" || identity.Level != "" ||
+		identity.MinPriorityScore != nil || identity.MaxPriorityScore != nil
+}
+
+func matchesURIGlob(glob string, result Result) bool {
+	pattern, err := compileURIGlob(glob)
+	if err != nil {
+		log.Printf("Warning: invalid uri-glob %q: %v", glob, err)
+		return false
+	}
+
+	for _, location := range result.Locations {
+		uri := location.PhysicalLocation.ArtifactLocation.URI
+		if pattern.MatchString(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileURIGlob turns a uri-glob pattern into a regexp, supporting "**" to
+// match across path separators (e.g. "test/**") and "*"/"?" to match within
+// a single path segment, since the standard library's path.Match can't
+// express "any depth under this directory".
+func compileURIGlob(glob string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				pattern.WriteString(".*")
+				i++
+			} else {
+				pattern.WriteString("[^/]*")
+			}
+		case '?':
+			pattern.WriteString("[^/]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(c)))
+		}
 	}
 
-	return !isExpired(identityConfig, currentTime)
+	pattern.WriteString("$")
+	return regexp.Compile(pattern.String())
 }
 
 func isExpired(identity Identity, currentTime time.Time) bool {
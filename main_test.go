@@ -72,7 +72,7 @@ identities:
 
 	// Run the filter function
 	currentTime, _ := time.Parse("2006-01-02", "2023-06-01") // Set a date before expiration
-	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime)
+	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, ModeDrop)
 
 	// Check the results
 	if len(filteredSarif.Runs) != 1 {
@@ -148,7 +148,7 @@ identities:
 
 	// Run the filter function
 	currentTime := time.Now()
-	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime)
+	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, ModeDrop)
 
 	// Check the results
 	if !reflect.DeepEqual(sarif, *filteredSarif) {
@@ -273,7 +273,7 @@ identities:
 	// Set a fixed current time for testing
 	currentTime, _ := time.Parse("2006-01-02", "2024-01-01")
 
-	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime)
+	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, ModeDrop)
 
 	// Check the results
 	if len(filteredSarif.Runs) != 1 {
@@ -290,3 +290,316 @@ identities:
 			expectedIdentity, filteredSarif.Runs[0].Results[0].Fingerprints["identity"])
 	}
 }
+
+func TestFilterIdentitiesSuppressMode(t *testing.T) {
+	sarifJSON := `{
+		"$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		"version": "2.1.0",
+		"runs": [
+			{
+				"tool": {
+					"driver": {
+						"name": "Test Tool",
+						"version": "1.0",
+						"rules": [
+							{"id": "TEST001", "name": "Test Rule 1"}
+						]
+					}
+				},
+				"results": [
+					{
+						"ruleId": "TEST001",
+						"message": {
+							"text": "Test result 1"
+						},
+						"fingerprints": {
+							"identity": "expired-id"
+						}
+					}
+				]
+			}
+		]
+	}`
+
+	var sarif Sarif
+	if err := json.Unmarshal([]byte(sarifJSON), &sarif); err != nil {
+		t.Fatalf("Failed to unmarshal test SARIF data: %v", err)
+	}
+
+	identitiesYAML := `
+identities:
+  expired-id:
+    enabled: true
+    reason: "Accepted risk"
+    expires-on: "2023-01-01"
+`
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(identitiesYAML), &config); err != nil {
+		t.Fatalf("Failed to parse identities YAML: %v", err)
+	}
+
+	currentTime, _ := time.Parse("2006-01-02", "2024-01-01")
+	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, ModeSuppress)
+
+	// The run's tool/rules must survive even though its only result is filtered.
+	if len(filteredSarif.Runs) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(filteredSarif.Runs))
+	}
+	if len(filteredSarif.Runs[0].Tool.Driver.Rules) != 1 {
+		t.Fatalf("Expected tool driver rules to be preserved, got %+v", filteredSarif.Runs[0].Tool.Driver.Rules)
+	}
+
+	// The result itself must be preserved, with a suppression attached.
+	if len(filteredSarif.Runs[0].Results) != 1 {
+		t.Fatalf("Expected 1 result to be preserved, got %d", len(filteredSarif.Runs[0].Results))
+	}
+
+	result := filteredSarif.Runs[0].Results[0]
+	if len(result.Suppressions) != 1 {
+		t.Fatalf("Expected 1 suppression, got %d", len(result.Suppressions))
+	}
+
+	suppression := result.Suppressions[0]
+	if suppression.Kind != "external" || suppression.Status != "accepted" {
+		t.Errorf("Expected kind=external status=accepted, got kind=%s status=%s", suppression.Kind, suppression.Status)
+	}
+	if suppression.Justification == "" {
+		t.Errorf("Expected a non-empty justification")
+	}
+}
+
+func TestFilterIdentitiesRichPredicates(t *testing.T) {
+	sarifJSON := `{
+		"$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		"version": "2.1.0",
+		"runs": [
+			{
+				"tool": {
+					"driver": {
+						"name": "Test Tool",
+						"version": "1.0"
+					}
+				},
+				"results": [
+					{
+						"ruleId": "javascript/XSS",
+						"level": "error",
+						"message": {"text": "XSS in test fixture"},
+						"locations": [
+							{"physicalLocation": {"artifactLocation": {"uri": "test/fixtures/xss.js"}}}
+						],
+						"fingerprints": {"identity": "11111111-1111-1111-1111-111111111111"}
+					},
+					{
+						"ruleId": "javascript/XSS",
+						"level": "error",
+						"message": {"text": "XSS in production code"},
+						"locations": [
+							{"physicalLocation": {"artifactLocation": {"uri": "src/app.js"}}}
+						],
+						"fingerprints": {"identity": "22222222-2222-2222-2222-222222222222"}
+					}
+				]
+			}
+		]
+	}`
+
+	var sarif Sarif
+	if err := json.Unmarshal([]byte(sarifJSON), &sarif); err != nil {
+		t.Fatalf("Failed to unmarshal test SARIF data: %v", err)
+	}
+
+	identitiesYAML := `
+defaults:
+  reason: "Known noisy rule"
+identities:
+  suppress-test-xss:
+    enabled: true
+    rule-id: "javascript/XSS"
+    uri-glob: "test/**"
+`
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(identitiesYAML), &config); err != nil {
+		t.Fatalf("Failed to parse identities YAML: %v", err)
+	}
+	config.applyDefaults()
+
+	currentTime := time.Now()
+	filteredSarif := filterIdentities(&sarif, config.Identities, currentTime, ModeDrop)
+
+	if len(filteredSarif.Runs[0].Results) != 1 {
+		t.Fatalf("Expected 1 result to remain, got %d", len(filteredSarif.Runs[0].Results))
+	}
+
+	remaining := filteredSarif.Runs[0].Results[0]
+	if remaining.Fingerprints["identity"] != "22222222-2222-2222-2222-222222222222" {
+		t.Errorf("Expected the production-code result to remain, got %+v", remaining)
+	}
+
+	if config.Identities["suppress-test-xss"].Reason != "Known noisy rule" {
+		t.Errorf("Expected entry to inherit the default reason, got %q", config.Identities["suppress-test-xss"].Reason)
+	}
+}
+
+func TestFindMatchStableWithOverlappingEntries(t *testing.T) {
+	result := Result{
+		RuleID: "javascript/XSS",
+		Fingerprints: map[string]string{
+			"identity": "11111111-1111-1111-1111-111111111111",
+		},
+	}
+
+	identities := map[string]Identity{
+		"11111111-1111-1111-1111-111111111111": {
+			Enabled:   true,
+			Reason:    "legacy fingerprint entry",
+			ExpiresOn: "2023-01-01", // expired, so the legacy path matches
+		},
+		"rule-based-entry": {
+			Enabled: true,
+			Reason:  "rule-id entry",
+			RuleID:  "javascript/XSS",
+		},
+	}
+
+	currentTime, _ := time.Parse("2006-01-02", "2024-01-01")
+
+	var firstName string
+	for i := 0; i < 200; i++ {
+		name, identity, ok := findMatch(result, identities, currentTime)
+		if !ok {
+			t.Fatalf("Expected a match, got none on iteration %d", i)
+		}
+		if i == 0 {
+			firstName = name
+		} else if name != firstName || identity.Reason != identities[firstName].Reason {
+			t.Fatalf("findMatch was not stable across runs: iteration %d picked %q, iteration 0 picked %q", i, name, firstName)
+		}
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	sarifJSON := `{
+		"version": "2.1.0",
+		"runs": [
+			{
+				"tool": {"driver": {"name": "Test Tool", "version": "1.0"}},
+				"results": [
+					{"ruleId": "TEST001", "fingerprints": {"identity": "expired-id"}},
+					{"ruleId": "TEST002", "fingerprints": {"identity": "unknown-id"}}
+				]
+			}
+		]
+	}`
+
+	var sarif Sarif
+	if err := json.Unmarshal([]byte(sarifJSON), &sarif); err != nil {
+		t.Fatalf("Failed to unmarshal test SARIF data: %v", err)
+	}
+
+	identitiesYAML := `
+identities:
+  expired-id:
+    enabled: true
+    reason: "Expired identity"
+    expires-on: "2023-01-01"
+  never-matches:
+    enabled: true
+    reason: "Dead policy"
+`
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(identitiesYAML), &config); err != nil {
+		t.Fatalf("Failed to parse identities YAML: %v", err)
+	}
+
+	currentTime, _ := time.Parse("2006-01-02", "2024-01-01")
+	report := buildReport(&sarif, config.Identities, currentTime, ModeDrop)
+
+	var expired, neverMatches *IdentityActivity
+	for i := range report.Identities {
+		switch report.Identities[i].Name {
+		case "expired-id":
+			expired = &report.Identities[i]
+		case "never-matches":
+			neverMatches = &report.Identities[i]
+		}
+	}
+
+	if expired == nil || !expired.Expired || expired.MatchedResults != 1 || expired.DeadPolicy {
+		t.Errorf("Unexpected expired-id activity: %+v", expired)
+	}
+	if neverMatches == nil || !neverMatches.DeadPolicy || neverMatches.MatchedResults != 0 {
+		t.Errorf("Expected never-matches to be flagged as a dead policy, got %+v", neverMatches)
+	}
+
+	if len(report.Rules) != 2 {
+		t.Fatalf("Expected 2 rule entries, got %d", len(report.Rules))
+	}
+	for _, rule := range report.Rules {
+		switch rule.RuleID {
+		case "TEST001":
+			if rule.Filtered != 1 || rule.Kept != 0 {
+				t.Errorf("Expected TEST001 to be filtered, got %+v", rule)
+			}
+		case "TEST002":
+			if rule.Kept != 1 || rule.Filtered != 0 {
+				t.Errorf("Expected TEST002 to be kept, got %+v", rule)
+			}
+		}
+	}
+}
+
+func TestBuildReportActiveFingerprintNotFlaggedDead(t *testing.T) {
+	sarifJSON := `{
+		"version": "2.1.0",
+		"runs": [
+			{
+				"tool": {"driver": {"name": "Test Tool", "version": "1.0"}},
+				"results": [
+					{"ruleId": "TEST001", "fingerprints": {"identity": "active-id"}}
+				]
+			}
+		]
+	}`
+
+	var sarif Sarif
+	if err := json.Unmarshal([]byte(sarifJSON), &sarif); err != nil {
+		t.Fatalf("Failed to unmarshal test SARIF data: %v", err)
+	}
+
+	identitiesYAML := `
+identities:
+  active-id:
+    enabled: true
+    reason: "Accepted risk"
+    expires-on: "2030-01-01"
+`
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(identitiesYAML), &config); err != nil {
+		t.Fatalf("Failed to parse identities YAML: %v", err)
+	}
+
+	currentTime, _ := time.Parse("2006-01-02", "2024-01-01")
+	report := buildReport(&sarif, config.Identities, currentTime, ModeDrop)
+
+	var active *IdentityActivity
+	for i := range report.Identities {
+		if report.Identities[i].Name == "active-id" {
+			active = &report.Identities[i]
+		}
+	}
+
+	if active == nil {
+		t.Fatal("Expected a report entry for active-id")
+	}
+	if active.MatchedResults != 1 {
+		t.Errorf("Expected active-id to have matched its 1 result, got %d", active.MatchedResults)
+	}
+	if active.DeadPolicy {
+		t.Errorf("Expected active-id, whose fingerprint appears in the input, not to be flagged dead")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// Report is the JSON summary produced by --report: what a filter pass
+// matched, which entries never fired (dead-policy detection), and how each
+// rule split between kept and filtered results.
+type Report struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Mode        string             `json:"mode"`
+	Identities  []IdentityActivity `json:"identities"`
+	Rules       []RuleActivity     `json:"rules"`
+}
+
+// IdentityActivity describes how much use a single policy entry got during
+// a filter pass.
+type IdentityActivity struct {
+	Name           string `json:"name"`
+	Enabled        bool   `json:"enabled"`
+	Expired        bool   `json:"expired"`
+	MatchedResults int    `json:"matchedResults"`
+	// DeadPolicy is true for an enabled, never-matched entry: its
+	// fingerprint/predicates no longer appear in scanner output, so it's
+	// likely safe to remove from the YAML.
+	DeadPolicy bool `json:"deadPolicy"`
+}
+
+// RuleActivity is the kept/filtered breakdown for a single SARIF rule ID.
+type RuleActivity struct {
+	RuleID   string `json:"ruleId"`
+	Kept     int    `json:"kept"`
+	Filtered int    `json:"filtered"`
+}
+
+// buildReport walks sarif the same way filterIdentities does, but records
+// per-identity match counts and per-rule kept/filtered totals instead of
+// producing a filtered document.
+func buildReport(sarif *Sarif, identities map[string]Identity, currentTime time.Time, mode string) *Report {
+	// targetCounts tracks how often each entry's predicates describe a
+	// result in the input, independent of matchEntry's suppression-timing
+	// semantics. The legacy fingerprint path only suppresses once an entry
+	// has expired, so using matchEntry here would flag every enabled,
+	// not-yet-expired fingerprint entry as "dead" even while its fingerprint
+	// keeps showing up in scans.
+	targetCounts := make(map[string]int, len(identities))
+	rules := make(map[string]*RuleActivity)
+
+	for _, run := range sarif.Runs {
+		for _, result := range run.Results {
+			rule, ok := rules[result.RuleID]
+			if !ok {
+				rule = &RuleActivity{RuleID: result.RuleID}
+				rules[result.RuleID] = rule
+			}
+
+			if _, _, suppressed := findMatch(result, identities, currentTime); suppressed {
+				rule.Filtered++
+			} else {
+				rule.Kept++
+			}
+
+			for name, identity := range identities {
+				if identityTargetsResult(name, identity, result) {
+					targetCounts[name]++
+				}
+			}
+		}
+	}
+
+	report := &Report{
+		GeneratedAt: currentTime,
+		Mode:        mode,
+		Identities:  make([]IdentityActivity, 0, len(identities)),
+		Rules:       make([]RuleActivity, 0, len(rules)),
+	}
+
+	for name, identity := range identities {
+		report.Identities = append(report.Identities, IdentityActivity{
+			Name:           name,
+			Enabled:        identity.Enabled,
+			Expired:        isExpired(identity, currentTime),
+			MatchedResults: targetCounts[name],
+			DeadPolicy:     identity.Enabled && targetCounts[name] == 0,
+		})
+	}
+	sort.Slice(report.Identities, func(i, j int) bool {
+		return report.Identities[i].Name < report.Identities[j].Name
+	})
+
+	for _, rule := range rules {
+		report.Rules = append(report.Rules, *rule)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		return report.Rules[i].RuleID < report.Rules[j].RuleID
+	})
+
+	return report
+}
+
+// withoutExpired drops expired entries from the identity breakdown, for
+// callers that only want to see currently-active policy usage.
+func (r *Report) withoutExpired() *Report {
+	trimmed := *r
+	trimmed.Identities = make([]IdentityActivity, 0, len(r.Identities))
+	for _, identity := range r.Identities {
+		if identity.Expired {
+			continue
+		}
+		trimmed.Identities = append(trimmed.Identities, identity)
+	}
+	return &trimmed
+}
+
+// Output formats for ResultsWriter's --output-format.
+const (
+	OutputFormatSarif = "sarif"
+	OutputFormatNone  = "none"
+)
+
+// ResultsWriter decouples the filter pass from how its output is presented:
+// whether the filtered SARIF is printed, and whether/where an audit report
+// is written.
+type ResultsWriter struct {
+	outputFormat   string
+	includeExpired bool
+	reportPath     string
+}
+
+// NewResultsWriter returns a writer that prints filtered SARIF to stdout and
+// includes expired entries in any report, matching the tool's long-standing
+// default behavior.
+func NewResultsWriter() *ResultsWriter {
+	return &ResultsWriter{
+		outputFormat:   OutputFormatSarif,
+		includeExpired: true,
+	}
+}
+
+// SetOutputFormat chooses how the filtered SARIF itself is presented.
+// OutputFormatSarif (the default) prints it to stdout; OutputFormatNone
+// suppresses it, for callers that only want the --report summary.
+func (w *ResultsWriter) SetOutputFormat(format string) *ResultsWriter {
+	w.outputFormat = format
+	return w
+}
+
+// SetIncludeExpired controls whether expired identity entries appear in the
+// report's identity breakdown.
+func (w *ResultsWriter) SetIncludeExpired(include bool) *ResultsWriter {
+	w.includeExpired = include
+	return w
+}
+
+// SetReportPath sets where the JSON audit report is written. An empty path
+// (the default) skips writing a report entirely.
+func (w *ResultsWriter) SetReportPath(path string) *ResultsWriter {
+	w.reportPath = path
+	return w
+}
+
+// Write prints the filtered SARIF (unless suppressed) and, if a report path
+// was set, writes the audit report as JSON.
+func (w *ResultsWriter) Write(filteredSarif *Sarif, report *Report) error {
+	if w.outputFormat != OutputFormatNone {
+		sarifJSON, err := json.MarshalIndent(filteredSarif, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(sarifJSON))
+	}
+
+	if w.reportPath == "" || report == nil {
+		return nil
+	}
+
+	outgoing := report
+	if !w.includeExpired {
+		outgoing = report.withoutExpired()
+	}
+
+	reportJSON, err := json.MarshalIndent(outgoing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(w.reportPath, reportJSON, 0644)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/fsnotify.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// IdentityStore holds a hot-reloadable set of identities, guarded by a
+// RWMutex so concurrent /filter requests always see a consistent snapshot
+// while the background watcher or poller swaps in a freshly loaded map.
+type IdentityStore struct {
+	mu         sync.RWMutex
+	identities map[string]Identity
+}
+
+func newIdentityStore() *IdentityStore {
+	return &IdentityStore{identities: make(map[string]Identity)}
+}
+
+// Snapshot returns a copy of the current identities, safe for the caller to
+// range over without holding the store's lock.
+func (s *IdentityStore) Snapshot() map[string]Identity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]Identity, len(s.identities))
+	for k, v := range s.identities {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+func (s *IdentityStore) set(identities map[string]Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities = identities
+}
+
+func (s *IdentityStore) load(data []byte) error {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	config.applyDefaults()
+	s.set(config.Identities)
+	log.Printf("Loaded %d identities", len(config.Identities))
+	return nil
+}
+
+// reloadFile reads and parses the identities YAML at path into the store.
+func (s *IdentityStore) reloadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return s.load(data)
+}
+
+// watchFile hot-reloads the store whenever the local YAML file at path
+// changes, so operators can update suppressions without restarting. It
+// watches the file's parent directory rather than the file itself: editors
+// and ConfigMap symlink updates save atomically via write-temp-then-rename,
+// which replaces the watched inode and would otherwise silently kill a
+// watch placed directly on the file after the very first update.
+func (s *IdentityStore) watchFile(path string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	name := filepath.Base(path)
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Base(event.Name) != name {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := s.reloadFile(path); err != nil {
+					log.Printf("Warning: failed to reload identities from %s: %v", path, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Warning: identities watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// pollURL periodically re-fetches the YAML from url, using ETag/Last-Modified
+// conditional requests so an unchanged upstream registry never triggers a
+// reload.
+func (s *IdentityStore) pollURL(url string, refresh time.Duration) {
+	var etag, lastModified string
+
+	fetch := func() {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			log.Printf("Warning: failed to build request for %s: %v", url, err)
+			return
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("Warning: failed to fetch identities from %s: %v", url, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotModified {
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("Warning: failed to fetch identities from %s: status code %d", url, resp.StatusCode)
+			return
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			log.Printf("Warning: failed to read identities response from %s: %v", url, err)
+			return
+		}
+
+		if err := s.load(data); err != nil {
+			log.Printf("Warning: failed to parse identities from %s: %v", url, err)
+			return
+		}
+
+		etag = resp.Header.Get("ETag")
+		lastModified = resp.Header.Get("Last-Modified")
+	}
+
+	fetch()
+
+	go func() {
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			fetch()
+		}
+	}()
+}
+
+// newServeCommand builds the "serve" subcommand, which runs identity-filter
+// as a long-lived HTTP filtering sidecar for CI webhooks and scanner
+// integrations, instead of a one-shot CLI invocation.
+func newServeCommand() *cobra.Command {
+	cmdServe := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an HTTP server that filters SARIF files on demand",
+		Run: func(cmd *cobra.Command, args []string) {
+			addr, _ := cmd.Flags().GetString("addr")
+			identitiesSource, _ := cmd.Flags().GetString("identities-file")
+			refresh, _ := cmd.Flags().GetDuration("refresh")
+			mode, _ := cmd.Flags().GetString("mode")
+
+			if identitiesSource == "" {
+				log.Fatal("Please provide the --identities-file flag")
+			}
+			if mode != ModeDrop && mode != ModeSuppress {
+				log.Fatalf("Invalid --mode %q, must be %q or %q", mode, ModeDrop, ModeSuppress)
+			}
+
+			store := newIdentityStore()
+
+			switch {
+			case strings.HasPrefix(identitiesSource, "http://") || strings.HasPrefix(identitiesSource, "https://"):
+				store.pollURL(identitiesSource, refresh)
+			case strings.HasPrefix(identitiesSource, "consul://"):
+				if err := store.watchConsul(identitiesSource); err != nil {
+					log.Fatal(err)
+				}
+			default:
+				if err := store.reloadFile(identitiesSource); err != nil {
+					log.Fatal(err)
+				}
+				if err := store.watchFile(identitiesSource); err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/healthz", handleHealthz)
+			mux.HandleFunc("/filter", handleFilter(store, mode))
+
+			log.Printf("Listening on %s", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmdServe.Flags().String("addr", ":8080", "Address to listen on")
+	cmdServe.Flags().StringP("identities-file", "i", "", "Path, URL, or consul://host:port/kv/path to the YAML file with identities (note: expires-on suppresses after the date for plain fingerprint entries, but until the date for rule-id/uri-glob/level/priority-score entries; see Identity)")
+	cmdServe.Flags().Duration("refresh", 30*time.Second, "Poll interval used when --identities-file is a URL (consul:// sources use blocking queries instead)")
+	cmdServe.Flags().String("mode", ModeDrop, "How to handle filtered results: \"drop\" or \"suppress\"")
+
+	return cmdServe
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func handleFilter(store *IdentityStore, mode string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var sarif Sarif
+		if err := json.Unmarshal(body, &sarif); err != nil {
+			http.Error(w, fmt.Sprintf("invalid SARIF body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		filteredSarif := filterIdentities(&sarif, store.Snapshot(), time.Now(), mode)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(filteredSarif); err != nil {
+			log.Printf("Warning: failed to write filter response: %v", err)
+		}
+	}
+}
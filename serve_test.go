@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentityStoreLoadSetSnapshot(t *testing.T) {
+	store := newIdentityStore()
+
+	if err := store.load([]byte(`
+identities:
+  abc:
+    enabled: true
+    reason: "test"
+`)); err != nil {
+		t.Fatalf("Failed to load identities: %v", err)
+	}
+
+	snapshot := store.Snapshot()
+	if len(snapshot) != 1 || !snapshot["abc"].Enabled {
+		t.Fatalf("Expected snapshot to contain enabled identity \"abc\", got %+v", snapshot)
+	}
+
+	// Mutating the returned snapshot must not affect the store.
+	delete(snapshot, "abc")
+	if len(store.Snapshot()) != 1 {
+		t.Errorf("Expected store's identities to be unaffected by snapshot mutation")
+	}
+
+	store.set(map[string]Identity{"xyz": {Enabled: true}})
+	snapshot = store.Snapshot()
+	if _, ok := snapshot["xyz"]; !ok || len(snapshot) != 1 {
+		t.Errorf("Expected set() to replace the store's identities, got %+v", snapshot)
+	}
+}
+
+func TestPollURLConditionalGet(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if r.Header.Get("If-None-Match") == "etag-1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "etag-1")
+		w.Write([]byte(`
+identities:
+  abc:
+    enabled: true
+`))
+	}))
+	defer server.Close()
+
+	store := newIdentityStore()
+	store.pollURL(server.URL, time.Hour)
+
+	if len(store.Snapshot()) != 1 {
+		t.Fatalf("Expected the initial fetch to load 1 identity, got %+v", store.Snapshot())
+	}
+	if requestCount != 1 {
+		t.Fatalf("Expected 1 request after initial fetch, got %d", requestCount)
+	}
+}
+
+func TestHandleHealthz(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	handleHealthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+}
+
+func TestHandleFilter(t *testing.T) {
+	store := newIdentityStore()
+	if err := store.load([]byte(`
+identities:
+  expired-id:
+    enabled: true
+    expires-on: "2023-01-01"
+`)); err != nil {
+		t.Fatalf("Failed to load identities: %v", err)
+	}
+
+	handler := handleFilter(store, ModeDrop)
+
+	sarifBody := `{
+		"version": "2.1.0",
+		"runs": [
+			{
+				"tool": {"driver": {"name": "Test Tool"}},
+				"results": [
+					{"ruleId": "TEST001", "fingerprints": {"identity": "expired-id"}}
+				]
+			}
+		]
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(sarifBody))
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if strings.Contains(recorder.Body.String(), "expired-id") {
+		t.Errorf("Expected the expired-id result to be filtered out, got %s", recorder.Body.String())
+	}
+}
+
+func TestHandleFilterRejectsNonPost(t *testing.T) {
+	store := newIdentityStore()
+	handler := handleFilter(store, ModeDrop)
+
+	req := httptest.NewRequest(http.MethodGet, "/filter", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", recorder.Code)
+	}
+}
+
+func TestHandleFilterRejectsInvalidBody(t *testing.T) {
+	store := newIdentityStore()
+	handler := handleFilter(store, ModeDrop)
+
+	req := httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader("not json"))
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", recorder.Code)
+	}
+}